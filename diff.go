@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ChangeKind categorizes how a symbol differs between two package
+// versions.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// Compatibility says whether a change can break a consumer of the
+// package's API.
+type Compatibility string
+
+const (
+	CompatBackward Compatibility = "backward-compatible"
+	CompatBreaking Compatibility = "breaking"
+)
+
+// PackageDiff pairs one package's diff with the package itself, for
+// reporting diffs across multiple packages (e.g. a "./..." pattern) in a
+// single run.
+type PackageDiff struct {
+	Package string         `json:"package"`
+	Changes []SymbolChange `json:"changes"`
+}
+
+// SymbolChange describes one difference between two exported APIs.
+type SymbolChange struct {
+	Kind          ChangeKind    `json:"kind"`
+	Name          string        `json:"name"`
+	Detail        string        `json:"detail"`
+	Compatibility Compatibility `json:"compatibility"`
+}
+
+// String renders a SymbolChange as a single greppable line:
+// "<compatibility>\t<kind>\t<name>\t<detail>".
+func (c SymbolChange) String() string {
+	return fmt.Sprintf("%s\t%s\t%s\t%s", c.Compatibility, c.Kind, c.Name, c.Detail)
+}
+
+// diffPackages reports every exported-API difference between before and
+// after, sorted by symbol name.
+func diffPackages(before, after *packages.Package) ([]SymbolChange, error) {
+	if before.Types == nil {
+		return nil, fmt.Errorf("package %q was not type-checked", before.PkgPath)
+	}
+	if after.Types == nil {
+		return nil, fmt.Errorf("package %q was not type-checked", after.PkgPath)
+	}
+	return diffSymbols(exportedSymbols(before.Types), exportedSymbols(after.Types)), nil
+}
+
+// diffPackageSets compares two sets of packages, one per side of a diff
+// pattern. If both sides are a single package, they are compared
+// directly regardless of import path: this is the common "diff v1 v2"
+// case, where the two package paths are intentionally different (e.g.
+// two checkouts of the same package at different revisions). Otherwise
+// packages are paired by matching import path, so a "./..." pattern
+// compares each package to its actual counterpart rather than to
+// whatever happens to be next to it in sorted order; a package present
+// on only one side is reported as entirely added or removed.
+func diffPackageSets(before, after []*packages.Package) ([]PackageDiff, error) {
+	if len(before) == 1 && len(after) == 1 {
+		changes, err := diffPackages(before[0], after[0])
+		if err != nil {
+			return nil, err
+		}
+		return []PackageDiff{{Package: after[0].PkgPath, Changes: changes}}, nil
+	}
+
+	beforeByPath := packagesByPath(before)
+	afterByPath := packagesByPath(after)
+	paths := make(map[string]bool, len(beforeByPath)+len(afterByPath))
+	for path := range beforeByPath {
+		paths[path] = true
+	}
+	for path := range afterByPath {
+		paths[path] = true
+	}
+	sortedPaths := make([]string, 0, len(paths))
+	for path := range paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Strings(sortedPaths)
+
+	diffs := make([]PackageDiff, 0, len(sortedPaths))
+	for _, path := range sortedPaths {
+		b, hasBefore := beforeByPath[path]
+		a, hasAfter := afterByPath[path]
+		switch {
+		case hasBefore && hasAfter:
+			changes, err := diffPackages(b, a)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, PackageDiff{Package: path, Changes: changes})
+		case hasBefore && !hasAfter:
+			diffs = append(diffs, PackageDiff{Package: path, Changes: wholePackageChanges(b, ChangeRemoved, CompatBreaking)})
+		case !hasBefore && hasAfter:
+			diffs = append(diffs, PackageDiff{Package: path, Changes: wholePackageChanges(a, ChangeAdded, CompatBackward)})
+		}
+	}
+	return diffs, nil
+}
+
+func packagesByPath(pkgs []*packages.Package) map[string]*packages.Package {
+	m := make(map[string]*packages.Package, len(pkgs))
+	for _, pkg := range pkgs {
+		m[pkg.PkgPath] = pkg
+	}
+	return m
+}
+
+// wholePackageChanges reports every exported symbol in pkg as a single
+// SymbolChange of kind/compat, for a package that exists on only one
+// side of a multi-package diff.
+func wholePackageChanges(pkg *packages.Package, kind ChangeKind, compat Compatibility) []SymbolChange {
+	symbols := exportedSymbols(pkg.Types)
+	changes := make([]SymbolChange, 0, len(symbols))
+	for _, sym := range symbols {
+		changes = append(changes, SymbolChange{Kind: kind, Name: sym.Name, Detail: sym.Signature, Compatibility: compat})
+	}
+	return changes
+}
+
+func diffSymbols(before, after []Symbol) []SymbolChange {
+	beforeByName := symbolsByName(before)
+	afterByName := symbolsByName(after)
+
+	names := make(map[string]bool, len(beforeByName)+len(afterByName))
+	for name := range beforeByName {
+		names[name] = true
+	}
+	for name := range afterByName {
+		names[name] = true
+	}
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	var changes []SymbolChange
+	for _, name := range sortedNames {
+		b, hasBefore := beforeByName[name]
+		a, hasAfter := afterByName[name]
+		switch {
+		case hasBefore && !hasAfter:
+			changes = append(changes, SymbolChange{Kind: ChangeRemoved, Name: name, Detail: b.Signature, Compatibility: CompatBreaking})
+		case !hasBefore && hasAfter:
+			changes = append(changes, SymbolChange{Kind: ChangeAdded, Name: name, Detail: a.Signature, Compatibility: CompatBackward})
+		case b.Signature != a.Signature:
+			changes = append(changes, diffModifiedSymbol(b, a)...)
+		}
+	}
+	return changes
+}
+
+func symbolsByName(symbols []Symbol) map[string]Symbol {
+	m := make(map[string]Symbol, len(symbols))
+	for _, s := range symbols {
+		m[s.Name] = s
+	}
+	return m
+}
+
+// diffModifiedSymbol expands a changed symbol into one or more
+// SymbolChanges.
+//
+// For structs, it compares the member list so that a purely additive
+// change (a new field or method) is reported as backward-compatible even
+// though the type's overall signature changed.
+//
+// For interfaces, every added or removed method is breaking: an added
+// method is a new requirement every existing implementer fails to meet,
+// and a removed method changes the contract callers rely on. So, unlike
+// structs, additive interface changes are never downgraded to
+// backward-compatible.
+//
+// Anything else (before.Kind != after.Kind, e.g. struct-to-interface, or
+// a shape change the member list doesn't capture) is reported breaking
+// using the full before/after signatures.
+func diffModifiedSymbol(before, after Symbol) []SymbolChange {
+	if !isRecordKind(before.Kind) || before.Kind != after.Kind {
+		return []SymbolChange{{
+			Kind:          ChangeModified,
+			Name:          before.Name,
+			Detail:        fmt.Sprintf("%s -> %s", before.Signature, after.Signature),
+			Compatibility: CompatBreaking,
+		}}
+	}
+
+	added, removed := diffMembers(before.Members, after.Members)
+
+	if before.Kind == "interface" {
+		changes := make([]SymbolChange, 0, len(added)+len(removed))
+		for _, m := range removed {
+			changes = append(changes, SymbolChange{Kind: ChangeModified, Name: before.Name, Detail: "removed " + m, Compatibility: CompatBreaking})
+		}
+		for _, m := range added {
+			changes = append(changes, SymbolChange{Kind: ChangeModified, Name: before.Name, Detail: "added " + m, Compatibility: CompatBreaking})
+		}
+		if len(changes) == 0 {
+			changes = append(changes, SymbolChange{
+				Kind:          ChangeModified,
+				Name:          before.Name,
+				Detail:        fmt.Sprintf("%s -> %s", before.Signature, after.Signature),
+				Compatibility: CompatBreaking,
+			})
+		}
+		return changes
+	}
+
+	if len(removed) == 0 && len(added) > 0 {
+		changes := make([]SymbolChange, 0, len(added))
+		for _, m := range added {
+			changes = append(changes, SymbolChange{Kind: ChangeAdded, Name: before.Name, Detail: m, Compatibility: CompatBackward})
+		}
+		return changes
+	}
+
+	var changes []SymbolChange
+	for _, m := range removed {
+		changes = append(changes, SymbolChange{Kind: ChangeModified, Name: before.Name, Detail: "removed " + m, Compatibility: CompatBreaking})
+	}
+	for _, m := range added {
+		changes = append(changes, SymbolChange{Kind: ChangeModified, Name: before.Name, Detail: "added " + m, Compatibility: CompatBreaking})
+	}
+	if len(changes) == 0 {
+		// The member lists match but the overall signature doesn't, e.g. an
+		// unexported field's presence changed the underlying type string.
+		// Report it conservatively.
+		changes = append(changes, SymbolChange{
+			Kind:          ChangeModified,
+			Name:          before.Name,
+			Detail:        fmt.Sprintf("%s -> %s", before.Signature, after.Signature),
+			Compatibility: CompatBreaking,
+		})
+	}
+	return changes
+}
+
+// isRecordKind reports whether kind is a Symbol.Kind that carries a
+// Members list (struct or interface), as opposed to "type" (aliases and
+// other non-struct/interface named types), "func", "const", or "var".
+func isRecordKind(kind string) bool {
+	return kind == "struct" || kind == "interface"
+}
+
+func diffMembers(before, after []string) (added, removed []string) {
+	beforeSet := make(map[string]bool, len(before))
+	for _, m := range before {
+		beforeSet[m] = true
+	}
+	afterSet := make(map[string]bool, len(after))
+	for _, m := range after {
+		afterSet[m] = true
+	}
+	for _, m := range after {
+		if !beforeSet[m] {
+			added = append(added, m)
+		}
+	}
+	for _, m := range before {
+		if !afterSet[m] {
+			removed = append(removed, m)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
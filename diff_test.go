@@ -0,0 +1,137 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestDiffPackages(t *testing.T) {
+	before, err := loadPackage("./testdata/diffpkg/v1")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	after, err := loadPackage("./testdata/diffpkg/v2")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	changes, err := diffPackages(before, after)
+	if err != nil {
+		t.Fatalf("diffPackages: %s", err)
+	}
+
+	wantBreaking := map[string]bool{
+		"Removed/removed":  true,
+		"Changed/modified": true,
+	}
+	foundBreaking := map[string]bool{}
+	foundAdded := false
+	foundFieldAdd := false
+	foundInterfaceMethodAdd := false
+	for _, c := range changes {
+		switch {
+		case c.Name == "Removed" && c.Kind == ChangeRemoved:
+			foundBreaking["Removed/removed"] = true
+			if c.Compatibility != CompatBreaking {
+				t.Errorf("Removed func should be breaking, got %s", c.Compatibility)
+			}
+		case c.Name == "Changed" && c.Kind == ChangeModified:
+			foundBreaking["Changed/modified"] = true
+			if c.Compatibility != CompatBreaking {
+				t.Errorf("Changed func should be breaking, got %s", c.Compatibility)
+			}
+		case c.Name == "Added" && c.Kind == ChangeAdded:
+			foundAdded = true
+			if c.Compatibility != CompatBackward {
+				t.Errorf("Added func should be backward-compatible, got %s", c.Compatibility)
+			}
+		case c.Name == "Thing" && c.Kind == ChangeAdded:
+			foundFieldAdd = true
+			if c.Compatibility != CompatBackward {
+				t.Errorf("Thing gaining a field should be backward-compatible, got %s", c.Compatibility)
+			}
+		case c.Name == "Greeter" && c.Kind == ChangeModified:
+			foundInterfaceMethodAdd = true
+			if c.Compatibility != CompatBreaking {
+				t.Errorf("Greeter gaining a method should be breaking, got %s", c.Compatibility)
+			}
+		}
+	}
+	for want := range wantBreaking {
+		if !foundBreaking[want] {
+			t.Errorf("missing expected change %q in %v", want, changes)
+		}
+	}
+	if !foundAdded {
+		t.Errorf("missing Added func in changes: %v", changes)
+	}
+	if !foundFieldAdd {
+		t.Errorf("missing Thing field addition in changes: %v", changes)
+	}
+	if !foundInterfaceMethodAdd {
+		t.Errorf("missing Greeter method addition in changes: %v", changes)
+	}
+}
+
+// TestDiffPackageSetsPairsByImportPath simulates comparing two checkouts
+// of a module where one package is unchanged, one was removed, and one
+// was added. Real packages.Load can't produce two package sets sharing
+// an import path within a single module, so the "shared" package here is
+// constructed directly rather than loaded twice under the same path.
+func TestDiffPackageSetsPairsByImportPath(t *testing.T) {
+	shared, err := loadPackage("./testdata/typespkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	removedOnly, err := loadPackage("./testdata/diffpkg/v1")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	addedOnly, err := loadPackage("./testdata/globalpkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+
+	before := []*packages.Package{
+		{PkgPath: "shared", Types: shared.Types},
+		{PkgPath: "removed", Types: removedOnly.Types},
+	}
+	after := []*packages.Package{
+		{PkgPath: "shared", Types: shared.Types},
+		{PkgPath: "added", Types: addedOnly.Types},
+	}
+
+	diffs, err := diffPackageSets(before, after)
+	if err != nil {
+		t.Fatalf("diffPackageSets: %s", err)
+	}
+	if len(diffs) != 3 {
+		t.Fatalf("expected 3 package diffs (shared, removed, added), got %d: %+v", len(diffs), diffs)
+	}
+	byPath := make(map[string]PackageDiff, len(diffs))
+	for _, d := range diffs {
+		byPath[d.Package] = d
+	}
+
+	if changes := byPath["shared"].Changes; len(changes) != 0 {
+		t.Errorf("shared package diffed against itself should have no changes, got %v", changes)
+	}
+	removedChanges, ok := byPath["removed"]
+	if !ok || len(removedChanges.Changes) == 0 {
+		t.Fatalf("expected whole-package removal for %q", "removed")
+	}
+	for _, c := range removedChanges.Changes {
+		if c.Kind != ChangeRemoved || c.Compatibility != CompatBreaking {
+			t.Errorf("removed-only package symbol %q should be removed/breaking, got %s/%s", c.Name, c.Kind, c.Compatibility)
+		}
+	}
+	addedChanges, ok := byPath["added"]
+	if !ok || len(addedChanges.Changes) == 0 {
+		t.Fatalf("expected whole-package addition for %q", "added")
+	}
+	for _, c := range addedChanges.Changes {
+		if c.Kind != ChangeAdded || c.Compatibility != CompatBackward {
+			t.Errorf("added-only package symbol %q should be added/backward-compatible, got %s/%s", c.Name, c.Kind, c.Compatibility)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/miolini/goexphash/internal/fastwalk"
+)
+
+// hashPackageDirHash computes a content-integrity hash for the package
+// directory at packagePath, compatible with the Go modules "h1:" dirhash
+// scheme (see golang.org/x/mod/sumdb/dirhash). Unlike the exported-symbol
+// hash computed by hashPackage, this hash changes whenever any file's
+// bytes change, regardless of whether that change affects the public API.
+//
+// Directory entries are enumerated with fastwalk so large package
+// directories are bounded by I/O rather than by one lstat(2) syscall per
+// file.
+func hashPackageDirHash(packagePath string) (dirHash string, err error) {
+	var mu sync.Mutex
+	var lines []string
+
+	walkErr := fastwalk.Walk(nil, packagePath, func(path string, typ os.FileMode) error {
+		if path == packagePath {
+			return nil
+		}
+		if typ.IsDir() {
+			return fastwalk.SkipDir
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(packagePath, path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		line := fmt.Sprintf("%x  %s\n", sum, filepath.ToSlash(rel))
+
+		mu.Lock()
+		lines = append(lines, line)
+		mu.Unlock()
+		return nil
+	})
+	if walkErr != nil {
+		return "", walkErr
+	}
+
+	sort.Strings(lines)
+	h := sha256.New()
+	for _, line := range lines {
+		h.Write([]byte(line))
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// dirHashForPackage computes the dirhash of the directory backing pkg, as
+// resolved by loadPackage.
+func dirHashForPackage(pkg *packages.Package) (string, error) {
+	files := pkg.GoFiles
+	if len(files) == 0 {
+		files = pkg.CompiledGoFiles
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("package %q has no files", pkg.PkgPath)
+	}
+	return hashPackageDirHash(filepath.Dir(files[0]))
+}
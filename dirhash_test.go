@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+// wantFixtureDirHash is the canonical h1 hash of testdata/fixturepkg,
+// recomputed independently of this package's implementation.
+const wantFixtureDirHash = "h1:Rx5sfN97xztTvDPRsVhCJN4nLtF5Q/bvpBPsrg50/QY="
+
+func TestHashPackageDirHash(t *testing.T) {
+	got, err := hashPackageDirHash("testdata/fixturepkg")
+	if err != nil {
+		t.Fatalf("hashPackageDirHash: %s", err)
+	}
+	if got != wantFixtureDirHash {
+		t.Fatalf("hashPackageDirHash = %q, want %q", got, wantFixtureDirHash)
+	}
+}
+
+func TestHashPackageDirHashStable(t *testing.T) {
+	first, err := hashPackageDirHash("testdata/fixturepkg")
+	if err != nil {
+		t.Fatalf("hashPackageDirHash: %s", err)
+	}
+	second, err := hashPackageDirHash("testdata/fixturepkg")
+	if err != nil {
+		t.Fatalf("hashPackageDirHash: %s", err)
+	}
+	if first != second {
+		t.Fatalf("hashPackageDirHash not deterministic: %q != %q", first, second)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// globalWalker computes the transitive API surface of a package by
+// following its import graph. Descriptors are cached per (import path,
+// module version) so diamond-shaped dependency graphs only pay the
+// go/types walk once per package.
+type globalWalker struct {
+	includeStdlib bool
+	visited       map[string]bool
+	descriptors   map[string]string
+}
+
+// hashGlobalAPI walks pkg's transitive imports and returns the sha512-256
+// digest of every included package's exported API, combined in
+// import-path-sorted order. Unlike the local hash, this changes whenever
+// any transitively imported package's exported API changes, even if pkg's
+// own source is untouched.
+func hashGlobalAPI(pkg *packages.Package, includeStdlib bool) (string, error) {
+	w := &globalWalker{
+		includeStdlib: includeStdlib,
+		visited:       map[string]bool{},
+		descriptors:   map[string]string{},
+	}
+	w.walk(pkg)
+
+	keys := make([]string, 0, len(w.descriptors))
+	for k := range w.descriptors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := bytes.Buffer{}
+	for _, k := range keys {
+		buf.WriteString(k)
+		buf.WriteString(newLine)
+		buf.WriteString(w.descriptors[k])
+		buf.WriteString(newLine)
+	}
+	return sha512String(buf), nil
+}
+
+func (w *globalWalker) walk(pkg *packages.Package) {
+	if pkg == nil || pkg.Types == nil {
+		return
+	}
+	cacheKey := packageCacheKey(pkg)
+	if w.visited[cacheKey] {
+		return
+	}
+	w.visited[cacheKey] = true
+
+	if w.includeStdlib || !isStdlibPackage(pkg) {
+		w.descriptors[pkg.PkgPath] = strings.Join(exportedDescriptors(pkg.Types), newLine)
+	}
+	for _, imp := range pkg.Imports {
+		w.walk(imp)
+	}
+}
+
+// packageCacheKey identifies a package by import path and, when known, its
+// module version, so the same import path resolved from two different
+// module versions is never conflated.
+func packageCacheKey(pkg *packages.Package) string {
+	if pkg.Module != nil && pkg.Module.Version != "" {
+		return pkg.PkgPath + "@" + pkg.Module.Version
+	}
+	return pkg.PkgPath
+}
+
+// isStdlibPackage reports whether pkg belongs to the standard library,
+// using the same heuristic as golang.org/x/tools: a package is standard
+// library if it has no module and its first import path segment contains
+// no dot (stdlib import paths never do; "github.com/..." etc. always do).
+func isStdlibPackage(pkg *packages.Package) bool {
+	if pkg.Module != nil {
+		return false
+	}
+	first := pkg.PkgPath
+	if i := strings.Index(first, "/"); i >= 0 {
+		first = first[:i]
+	}
+	return !strings.Contains(first, ".")
+}
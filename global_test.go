@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestHashGlobalAPIFollowsImports(t *testing.T) {
+	pkg, err := loadPackage("./testdata/globalpkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	withoutStdlib, err := hashGlobalAPI(pkg, false)
+	if err != nil {
+		t.Fatalf("hashGlobalAPI: %s", err)
+	}
+	withStdlib, err := hashGlobalAPI(pkg, true)
+	if err != nil {
+		t.Fatalf("hashGlobalAPI: %s", err)
+	}
+	if withoutStdlib == withStdlib {
+		t.Fatalf("expected -stdlib to change the global hash, got the same value %q for both", withStdlib)
+	}
+
+	typespkgOnly, err := loadPackage("./testdata/typespkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	typespkgHash, err := hashGlobalAPI(typespkgOnly, false)
+	if err != nil {
+		t.Fatalf("hashGlobalAPI: %s", err)
+	}
+	if typespkgHash == withoutStdlib {
+		t.Fatalf("expected globalpkg's hash to differ from typespkg's own hash")
+	}
+}
+
+func TestHashGlobalAPIDeterministic(t *testing.T) {
+	pkg, err := loadPackage("./testdata/globalpkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	first, err := hashGlobalAPI(pkg, true)
+	if err != nil {
+		t.Fatalf("hashGlobalAPI: %s", err)
+	}
+	pkg, err = loadPackage("./testdata/globalpkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	second, err := hashGlobalAPI(pkg, true)
+	if err != nil {
+		t.Fatalf("hashGlobalAPI: %s", err)
+	}
+	if first != second {
+		t.Fatalf("hashGlobalAPI not deterministic: %q != %q", first, second)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"go/token"
+	"go/types"
+	"log"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Symbol is a canonical, type-checked descriptor for one exported
+// package-level object. It backs the export hash, the JSON report, and
+// the diff subcommand, so all three agree on what an exported symbol is.
+type Symbol struct {
+	Kind      string   `json:"kind"`              // "func", "const", "var", "type", "struct", "interface"
+	Name      string   `json:"name"`              // exported identifier
+	Signature string   `json:"signature"`         // full canonical descriptor
+	Members   []string `json:"members,omitempty"` // exported fields/methods, for Kind == "struct" or "interface"
+}
+
+// hashPackage computes the exported-API descriptor hash for pkg. pkg must
+// have been loaded with packages.NeedTypes so it is already type-checked;
+// descriptors are derived from the type-checked API (go/types) rather than
+// from AST text, so the hash is invariant under formatting, comments, and
+// renamed parameters.
+func hashPackage(pkg *packages.Package) (localHash, globalHash string, err error) {
+	if pkg.Types == nil {
+		err = fmt.Errorf("package %q was not type-checked", pkg.PkgPath)
+		return
+	}
+	symbols := exportedSymbols(pkg.Types)
+	localBuf := bytes.Buffer{}
+	for _, sym := range symbols {
+		if *flPrintDescriptor {
+			log.Printf("%s", sym.Signature)
+		}
+		localBuf.Write([]byte(sym.Signature))
+		localBuf.Write([]byte(newLine))
+	}
+	localHash = sha512String(localBuf)
+	globalHash, err = hashGlobalAPI(pkg, *flStdlib)
+	return
+}
+
+// exportedDescriptors returns one canonical, sorted descriptor string per
+// exported package-level object in pkg.
+func exportedDescriptors(pkg *types.Package) exportItems {
+	symbols := exportedSymbols(pkg)
+	items := make(exportItems, len(symbols))
+	for i, sym := range symbols {
+		items[i] = sym.Signature
+	}
+	return items
+}
+
+// exportedSymbols returns one Symbol per exported package-level object in
+// pkg, sorted by canonical descriptor so the result is stable regardless
+// of declaration order.
+func exportedSymbols(pkg *types.Package) []Symbol {
+	qualifier := types.RelativeTo(pkg)
+	scope := pkg.Scope()
+	symbols := make([]Symbol, 0, scope.Len())
+	for _, name := range scope.Names() {
+		if !token.IsExported(name) {
+			continue
+		}
+		symbols = append(symbols, describeObject(scope.Lookup(name), qualifier))
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Signature < symbols[j].Signature })
+	return symbols
+}
+
+// describeObject renders the canonical Symbol for a single exported
+// package-level object.
+func describeObject(obj types.Object, qualifier types.Qualifier) Symbol {
+	switch o := obj.(type) {
+	case *types.Func:
+		return Symbol{Kind: "func", Name: o.Name(), Signature: types.ObjectString(o, qualifier)}
+	case *types.Const:
+		sig := fmt.Sprintf("const %s %s %s = %s", o.Name(), types.TypeString(o.Type(), qualifier), o.Val().Kind(), o.Val().ExactString())
+		return Symbol{Kind: "const", Name: o.Name(), Signature: sig}
+	case *types.Var:
+		sig := fmt.Sprintf("var %s %s", o.Name(), types.TypeString(o.Type(), qualifier))
+		return Symbol{Kind: "var", Name: o.Name(), Signature: sig}
+	case *types.TypeName:
+		return describeTypeName(o, qualifier)
+	default:
+		return Symbol{Kind: "unknown", Name: obj.Name(), Signature: types.ObjectString(obj, qualifier)}
+	}
+}
+
+// describeTypeName renders a named type's full exported shape: its
+// underlying struct fields or interface methods, plus any exported methods
+// declared on the type itself.
+func describeTypeName(obj *types.TypeName, qualifier types.Qualifier) Symbol {
+	if obj.IsAlias() {
+		sig := fmt.Sprintf("type %s = %s", obj.Name(), types.TypeString(obj.Type(), qualifier))
+		return Symbol{Kind: "type", Name: obj.Name(), Signature: sig}
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		sig := fmt.Sprintf("type %s %s", obj.Name(), types.TypeString(obj.Type(), qualifier))
+		return Symbol{Kind: "type", Name: obj.Name(), Signature: sig}
+	}
+	members := []string{}
+	switch u := named.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if f.Exported() {
+				members = append(members, fmt.Sprintf("field %s %s", f.Name(), types.TypeString(f.Type(), qualifier)))
+			}
+		}
+	case *types.Interface:
+		// Use the full method set (NumMethods/Method), not just the
+		// explicitly declared methods (NumExplicitMethods/ExplicitMethod):
+		// an interface that embeds another interface inherits its methods,
+		// and a change to the embedded interface must show up here too.
+		for i := 0; i < u.NumMethods(); i++ {
+			m := u.Method(i)
+			if m.Exported() {
+				members = append(members, "method "+types.ObjectString(m, qualifier))
+			}
+		}
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		m := named.Method(i)
+		if m.Exported() {
+			members = append(members, "method "+types.ObjectString(m, qualifier))
+		}
+	}
+	sort.Strings(members)
+	kind := "type"
+	if _, ok := named.Underlying().(*types.Interface); ok {
+		kind = "interface"
+	} else if _, ok := named.Underlying().(*types.Struct); ok {
+		kind = "struct"
+	}
+	sig := fmt.Sprintf("type %s %s %s { %s }", obj.Name(), kind, types.TypeString(named.Underlying(), qualifier), strings.Join(members, "; "))
+	return Symbol{Kind: kind, Name: obj.Name(), Signature: sig, Members: members}
+}
+
+type exportItems []string
+
+func (e exportItems) Len() int {
+	return len(e)
+}
+
+func (e exportItems) Swap(i, j int) {
+	e[i], e[j] = e[j], e[i]
+}
+
+func (e exportItems) Less(i, j int) bool {
+	return e[i] < e[j]
+}
+
+func sha512String(buf bytes.Buffer) string {
+	hash := sha512.New512_256()
+	hash.Write(buf.Bytes())
+	return hex.EncodeToString(hash.Sum(nil))
+}
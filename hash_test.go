@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashPackageStable(t *testing.T) {
+	pkg, err := loadPackage("./testdata/typespkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	first, _, err := hashPackage(pkg)
+	if err != nil {
+		t.Fatalf("hashPackage: %s", err)
+	}
+	pkg, err = loadPackage("./testdata/typespkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	second, _, err := hashPackage(pkg)
+	if err != nil {
+		t.Fatalf("hashPackage: %s", err)
+	}
+	if first != second {
+		t.Fatalf("hashPackage not deterministic: %q != %q", first, second)
+	}
+}
+
+func TestExportedDescriptorsSkipUnexportedField(t *testing.T) {
+	pkg, err := loadPackage("./testdata/typespkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	items := exportedDescriptors(pkg.Types)
+	joined := strings.Join(items, "\n")
+	if strings.Contains(joined, "field z ") {
+		t.Fatalf("descriptor leaked unexported field: %s", joined)
+	}
+	if !strings.Contains(joined, "field X ") || !strings.Contains(joined, "field Y ") {
+		t.Fatalf("descriptor missing exported fields: %s", joined)
+	}
+	if !strings.Contains(joined, "Greeter).Greet") {
+		t.Fatalf("descriptor missing interface method: %s", joined)
+	}
+}
+
+func TestExportedDescriptorsIncludeEmbeddedInterfaceMethods(t *testing.T) {
+	pkg, err := loadPackage("./testdata/typespkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	symbols := exportedSymbols(pkg.Types)
+	var loudGreeter Symbol
+	found := false
+	for _, sym := range symbols {
+		if sym.Name == "LoudGreeter" {
+			loudGreeter = sym
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("descriptor missing LoudGreeter: %v", symbols)
+	}
+	joined := strings.Join(loudGreeter.Members, "\n")
+	if !strings.Contains(joined, "Greeter).Greet()") {
+		t.Fatalf("LoudGreeter members missing method inherited from embedded Greeter: %v", loudGreeter.Members)
+	}
+	if !strings.Contains(joined, "LoudGreeter).GreetLoudly()") {
+		t.Fatalf("LoudGreeter members missing its own declared method: %v", loudGreeter.Members)
+	}
+}
@@ -0,0 +1,153 @@
+// Package fastwalk provides a faster version of filepath.Walk for file
+// system scanning, modeled on the design of golang.org/x/tools/internal/fastwalk:
+// directories are scanned concurrently by a bounded worker pool, and on
+// platforms where the kernel's getdents(2) result already carries a file's
+// type, that type is used directly instead of issuing a separate lstat(2)
+// syscall per entry.
+package fastwalk
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// SkipDir is filepath.SkipDir, re-exported so callers returning it from
+// their walk function don't need to import path/filepath themselves.
+var SkipDir = filepath.SkipDir
+
+// Config controls the behavior of Walk.
+type Config struct {
+	// NumWorkers is the number of directories scanned concurrently.
+	// If <= 0, runtime.GOMAXPROCS(0) is used.
+	NumWorkers int
+}
+
+// walkFunc is called once for every file and directory encountered,
+// including root itself. typ is the entry's type bits (os.ModeDir,
+// os.ModeSymlink, 0 for regular files, ...), usually obtained without a
+// separate stat syscall. Returning SkipDir from a call for a directory
+// skips that directory's contents; returning it for a non-directory
+// skips the remaining entries of its containing directory.
+type walkFunc func(path string, typ os.FileMode) error
+
+// Walk walks the file tree rooted at root, calling fn for each file or
+// directory, including root. Unlike filepath.Walk, fn may be called from
+// multiple goroutines at once, so it must be safe for concurrent use.
+func Walk(conf *Config, root string, fn walkFunc) error {
+	numWorkers := 0
+	if conf != nil {
+		numWorkers = conf.NumWorkers
+	}
+	if numWorkers <= 0 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+
+	rootInfo, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	if err := fn(root, rootInfo.Mode()&os.ModeType); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+	if rootInfo.Mode()&os.ModeType != os.ModeDir {
+		return nil
+	}
+
+	w := &walker{
+		fn:   fn,
+		work: make(chan string, 1024),
+	}
+	w.wg.Add(1)
+	var workers sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		workers.Add(1)
+		go w.worker(&workers)
+	}
+	w.work <- root
+	go func() {
+		w.wg.Wait()
+		close(w.work)
+	}()
+	workers.Wait()
+
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	return w.err
+}
+
+type walker struct {
+	fn   walkFunc
+	work chan string
+	wg   sync.WaitGroup
+
+	stopped int32
+
+	errMu sync.Mutex
+	err   error
+}
+
+func (w *walker) worker(workers *sync.WaitGroup) {
+	defer workers.Done()
+	for dir := range w.work {
+		w.scanDir(dir)
+		w.wg.Done()
+	}
+}
+
+// enqueue schedules dir for scanning. It never blocks the caller: if the
+// work channel is momentarily full, the send happens on its own goroutine
+// so a slow/blocked worker can't deadlock the producer.
+func (w *walker) enqueue(dir string) {
+	w.wg.Add(1)
+	select {
+	case w.work <- dir:
+	default:
+		go func() { w.work <- dir }()
+	}
+}
+
+func (w *walker) fail(err error) {
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	if w.err == nil {
+		w.err = err
+		atomic.StoreInt32(&w.stopped, 1)
+	}
+}
+
+func (w *walker) scanDir(dir string) {
+	if atomic.LoadInt32(&w.stopped) != 0 {
+		return
+	}
+	err := readDir(dir, func(dirName, entName string, typ os.FileMode) error {
+		if atomic.LoadInt32(&w.stopped) != 0 {
+			return filepath.SkipDir
+		}
+		path := filepath.Join(dirName, entName)
+		err := w.fn(path, typ)
+		if err == nil {
+			if typ.IsDir() {
+				w.enqueue(path)
+			}
+			return nil
+		}
+		if err == filepath.SkipDir {
+			if typ.IsDir() {
+				// Don't descend into this directory; keep scanning siblings.
+				return nil
+			}
+			// Skip the remaining entries of the containing directory.
+			return err
+		}
+		return err
+	})
+	if err != nil && err != filepath.SkipDir {
+		w.fail(err)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd)
+// +build !linux,!darwin,!freebsd,!openbsd,!netbsd
+
+package fastwalk
+
+import "os"
+
+// readDir is the portable fallback used on platforms (Windows, js/wasm,
+// ...) without a getdents(2)-alike syscall plumbed through here. It uses
+// os.ReadDir, which already avoids a separate stat per entry on most
+// platforms via fs.DirEntry.Type().
+func readDir(dirName string, fn func(dirName, entName string, typ os.FileMode) error) error {
+	entries, err := os.ReadDir(dirName)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := fn(dirName, entry.Name(), entry.Type()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
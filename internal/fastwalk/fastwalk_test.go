@@ -0,0 +1,93 @@
+package fastwalk
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestWalkVisitsAllFiles(t *testing.T) {
+	root := t.TempDir()
+	want := []string{
+		"a.txt",
+		"sub/b.txt",
+		"sub/nested/c.txt",
+	}
+	for _, rel := range want {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var got []string
+	err := Walk(&Config{NumWorkers: 4}, root, func(path string, typ os.FileMode) error {
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if typ.IsDir() {
+			return nil
+		}
+		mu.Lock()
+		got = append(got, filepath.ToSlash(rel))
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %s", err)
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestWalkSkipDir(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{"keep/a.txt", "skip/b.txt"} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var got []string
+	err := Walk(nil, root, func(path string, typ os.FileMode) error {
+		if filepath.Base(path) == "skip" {
+			return SkipDir
+		}
+		if typ.IsDir() {
+			return nil
+		}
+		mu.Lock()
+		got = append(got, filepath.Base(path))
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %s", err)
+	}
+	if len(got) != 1 || got[0] != "a.txt" {
+		t.Fatalf("got %v, want [a.txt]", got)
+	}
+}
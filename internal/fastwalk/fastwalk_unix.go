@@ -0,0 +1,117 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd
+// +build linux darwin freebsd openbsd netbsd
+
+package fastwalk
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// blockSize is the buffer size used for each getdents(2) call. It must be
+// at least as large as the largest possible directory entry.
+const blockSize = 8 << 10
+
+// readDir reads the directory named by dirName and calls fn once per
+// entry (excluding "." and ".."), passing the entry's type bits when the
+// kernel's getdents(2) result already carries them (DT_DIR, DT_REG, ...),
+// which avoids a separate lstat(2) syscall per entry. It falls back to
+// Lstat only for entries whose type the kernel reports as DT_UNKNOWN,
+// which some filesystems (old XFS, some FUSE/NFS mounts) always report.
+func readDir(dirName string, fn func(dirName, entName string, typ os.FileMode) error) error {
+	fd, err := syscall.Open(dirName, syscall.O_RDONLY, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: dirName, Err: err}
+	}
+	defer syscall.Close(fd)
+
+	buf := make([]byte, blockSize)
+	bufp := 0
+	nbuf := 0
+	for {
+		if bufp >= nbuf {
+			bufp = 0
+			nbuf, err = syscall.ReadDirent(fd, buf)
+			if err != nil {
+				return os.NewSyscallError("readdirent", err)
+			}
+			if nbuf <= 0 {
+				return nil
+			}
+		}
+		consumed, name, typ := parseDirEnt(buf[bufp:nbuf])
+		bufp += consumed
+		if name == "" || name == "." || name == ".." {
+			continue
+		}
+		if typ == unknownFileMode {
+			fi, err := os.Lstat(dirName + "/" + name)
+			if err != nil {
+				// The entry may have been removed between getdents(2) and
+				// this lstat(2); that's a normal race, not a problem. Any
+				// other failure (e.g. a transient FUSE/NFS error) must be
+				// surfaced rather than silently dropping the entry: a
+				// dirhash that omits files without saying so is worse than
+				// one that fails loudly.
+				if os.IsNotExist(err) {
+					continue
+				}
+				return err
+			}
+			typ = fi.Mode() & os.ModeType
+		}
+		if err := fn(dirName, name, typ); err != nil {
+			return err
+		}
+	}
+}
+
+// unknownFileMode is a sentinel returned by parseDirEnt when the kernel
+// didn't tell us the entry's type (DT_UNKNOWN), so the caller must lstat.
+const unknownFileMode os.FileMode = 1 << 31
+
+// parseDirEnt parses a single directory entry out of buf, which holds
+// the raw bytes returned by one or more syscall.ReadDirent calls, and
+// returns the number of bytes consumed, the entry's name, and its type
+// translated to an os.FileMode (or unknownFileMode if not known).
+func parseDirEnt(buf []byte) (consumed int, name string, typ os.FileMode) {
+	if len(buf) < int(unsafe.Offsetof(syscall.Dirent{}.Name)) {
+		return len(buf), "", unknownFileMode
+	}
+	dirent := (*syscall.Dirent)(unsafe.Pointer(&buf[0]))
+	if dirent.Reclen == 0 {
+		return len(buf), "", unknownFileMode
+	}
+	consumed = int(dirent.Reclen)
+	if consumed > len(buf) {
+		consumed = len(buf)
+	}
+
+	nameBuf := (*[unsafe.Sizeof(dirent.Name)]byte)(unsafe.Pointer(&dirent.Name))
+	nameLen := 0
+	for nameLen < len(nameBuf) && nameBuf[nameLen] != 0 {
+		nameLen++
+	}
+	name = string(nameBuf[:nameLen])
+
+	switch dirent.Type {
+	case syscall.DT_DIR:
+		typ = os.ModeDir
+	case syscall.DT_LNK:
+		typ = os.ModeSymlink
+	case syscall.DT_REG:
+		typ = 0
+	case syscall.DT_FIFO:
+		typ = os.ModeNamedPipe
+	case syscall.DT_SOCK:
+		typ = os.ModeSocket
+	case syscall.DT_CHR:
+		typ = os.ModeDevice | os.ModeCharDevice
+	case syscall.DT_BLK:
+		typ = os.ModeDevice
+	default:
+		typ = unknownFileMode
+	}
+	return consumed, name, typ
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadPackage resolves pattern (an import path, a relative path, or a
+// pattern such as "./...") to a single *packages.Package using the same
+// module/vendor/GOPATH resolution rules as the go command itself. It
+// replaces the old GOPATH-only lookupPackagePath, so goexphash works for
+// module-mode projects and vendored trees.
+func loadPackage(pattern string) (*packages.Package, error) {
+	pkgs, err := loadPackages(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("pattern %q must match exactly one package, matched %d", pattern, len(pkgs))
+	}
+	return pkgs[0], nil
+}
+
+// loadPackages resolves pattern to one or more packages, e.g. for
+// patterns like "./..." that expand to several packages. It errors if
+// pattern matches no packages at all, since that's almost always a typo'd
+// path or import pattern rather than an intentionally empty result.
+func loadPackages(pattern string) ([]*packages.Package, error) {
+	if *flVerbose {
+		log.Printf("load package: %s", pattern)
+	}
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax |
+			packages.NeedModule,
+		Env: append(osEnviron(), "GOOS="+*flGOOS, "GOARCH="+*flGOARCH),
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("load package %q: %w", pattern, err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("package %q has errors", pattern)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("pattern %q matched no packages", pattern)
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestLoadPackage(t *testing.T) {
+	pkg, err := loadPackage("./testdata/fixturepkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	if pkg.Name != "fixturepkg" {
+		t.Fatalf("pkg.Name = %q, want %q", pkg.Name, "fixturepkg")
+	}
+	if len(pkg.Syntax) != 2 {
+		t.Fatalf("len(pkg.Syntax) = %d, want 2", len(pkg.Syntax))
+	}
+}
+
+func TestDirHashForPackage(t *testing.T) {
+	pkg, err := loadPackage("./testdata/fixturepkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	got, err := dirHashForPackage(pkg)
+	if err != nil {
+		t.Fatalf("dirHashForPackage: %s", err)
+	}
+	if got != wantFixtureDirHash {
+		t.Fatalf("dirHashForPackage = %q, want %q", got, wantFixtureDirHash)
+	}
+}
@@ -1,211 +1,186 @@
 package main
 
 import (
-	"bytes"
-	"crypto/sha512"
-	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/printer"
-	"go/token"
 	"log"
 	"os"
-	"os/exec"
 	"sort"
-	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
 var (
-	newLine       = "\n"
-	newLineDouble = "\n\n"
+	newLine = "\n"
 
 	flVerbose         = flag.Bool("v", false, "verbose mode")
 	flPrintDescriptor = flag.Bool("p", false, "print descriptor")
-	flDownloadPackage = flag.Bool("d", false, "run go get tool")
+	flHashMode        = flag.String("hash-mode", "exports", "hash mode: exports, dirhash, both")
+	flGOOS            = flag.String("goos", os.Getenv("GOOS"), "target GOOS used to resolve build constraints")
+	flGOARCH          = flag.String("goarch", os.Getenv("GOARCH"), "target GOARCH used to resolve build constraints")
+	flStdlib          = flag.Bool("stdlib", true, "include standard library packages in the global hash")
+	flFormat          = flag.String("format", "text", "output format: text, json")
 )
 
 func main() {
+	flag.Parse()
 	if *flVerbose {
 		log.Print("GoExpHash - exported symbols hash calculator")
 	}
-	flag.Parse()
 	args := flag.Args()
-	if flag.NArg() != 1 {
-		log.Fatal("usage: goexphash <package name>")
+	if len(args) > 0 && args[0] == "diff" {
+		runDiff(args[1:])
+		return
 	}
-	packageName := args[len(args)-1]
+	if len(args) != 1 {
+		log.Fatal("usage: goexphash [flags] <package pattern>\n       goexphash diff <pkgA> <pkgB>")
+	}
+	pattern := args[0]
 	if *flVerbose {
-		log.Printf("hash package: %s", packageName)
+		log.Printf("hash package: %s", pattern)
 	}
-	localHash, _, err := hashPackage(packageName)
+	pkgs, err := loadPackages(pattern)
 	if err != nil {
 		log.Fatalf("error: %s", err)
 	}
-	fmt.Println(localHash)
-}
-
-func runCmd(execCmd string, args ...string) error {
-	cmd := exec.Command(execCmd, args...)
-	if *flVerbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	}
-	return cmd.Run()
-}
-
-func lookupPackagePath(packageName string) (path string, err error) {
-	if *flVerbose {
-		log.Printf("lookup package path: %s", packageName)
-	}
-	gopath, ok := os.LookupEnv("GOPATH")
-	if !ok {
-		err = fmt.Errorf("GOPATH not found")
+	sortPackagesByPath(pkgs)
+	if *flFormat == "json" {
+		runJSON(pkgs)
 		return
 	}
-	gopathDirs := strings.Split(gopath, ":")
+	for _, pkg := range pkgs {
+		if len(pkgs) > 1 {
+			fmt.Printf("%s:\n", pkg.PkgPath)
+		}
+		switch *flHashMode {
+		case "exports":
+			localHash, globalHash, err := hashPackage(pkg)
+			if err != nil {
+				log.Fatalf("error: %s", err)
+			}
+			fmt.Printf("local:  %s\n", localHash)
+			fmt.Printf("global: %s\n", globalHash)
+		case "dirhash":
+			dirHash, err := dirHashForPackage(pkg)
+			if err != nil {
+				log.Fatalf("error: %s", err)
+			}
+			fmt.Println(dirHash)
+		case "both":
+			localHash, globalHash, err := hashPackage(pkg)
+			if err != nil {
+				log.Fatalf("error: %s", err)
+			}
+			dirHash, err := dirHashForPackage(pkg)
+			if err != nil {
+				log.Fatalf("error: %s", err)
+			}
+			fmt.Printf("local:  %s\n", localHash)
+			fmt.Printf("global: %s\n", globalHash)
+			fmt.Printf("dirhash: %s\n", dirHash)
+		default:
+			log.Fatalf("unknown -hash-mode: %s", *flHashMode)
+		}
+	}
+}
 
-	if *flDownloadPackage {
-		err = runCmd("go", "get", "-u", "-v", packageName)
+// runJSON prints the full structured report (hashes, imports, exported
+// symbols) for each of pkgs as JSON. A single-package pattern prints one
+// object, matching the tool's previous output; a multi-package pattern
+// such as "./..." prints a JSON array, one report per matched package.
+func runJSON(pkgs []*packages.Package) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if len(pkgs) == 1 {
+		report, err := buildReport(pkgs[0])
 		if err != nil {
-			err = fmt.Errorf("go get err: %s", err)
-			return
+			log.Fatalf("error: %s", err)
+		}
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("error: %s", err)
 		}
-		path = gopathDirs[0] + "/src/" + packageName
 		return
 	}
-
-	for _, gopathDir := range gopathDirs {
-		tmppath := gopathDir + "/src/" + packageName
-		if ok, err = exists(tmppath); err != nil {
-			err = fmt.Errorf("check exists path '%s' error: %s", tmppath, err)
-			return
-		} else if ok {
-			path = tmppath
-			return
+	reports := make([]*PackageReport, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		report, err := buildReport(pkg)
+		if err != nil {
+			log.Fatalf("error: %s", err)
 		}
+		reports = append(reports, report)
 	}
-	return
-}
-
-func exists(path string) (bool, error) {
-	_, err := os.Stat(path)
-	if err == nil {
-		return true, nil
-	}
-	if os.IsNotExist(err) {
-		return false, nil
+	if err := enc.Encode(reports); err != nil {
+		log.Fatalf("error: %s", err)
 	}
-	return true, err
-}
-
-type exportItems []string
-
-func (e exportItems) Len() int {
-	return len(e)
 }
 
-func (e exportItems) Swap(i, j int) {
-	e[i], e[j] = e[j], e[i]
+// sortPackagesByPath sorts pkgs by import path so multi-package output is
+// stable across runs regardless of the order packages.Load returned them.
+func sortPackagesByPath(pkgs []*packages.Package) {
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
 }
 
-func (e exportItems) Less(i, j int) bool {
-	return e[i] < e[j]
-}
-
-func hashPackage(packageName string) (localHash, globalHash string, err error) {
-	localBuf := bytes.Buffer{}
-	globalBuf := bytes.Buffer{}
-	packagePath, err := lookupPackagePath(packageName)
+// runDiff implements "goexphash diff <pkgA> <pkgB>": it loads the
+// packages matched by each pattern and reports added/removed/changed
+// symbols, categorized as backward-compatible or breaking. See
+// diffPackageSets for how patterns that match more than one package
+// (e.g. "./...") are paired up.
+func runDiff(pkgNames []string) {
+	if len(pkgNames) != 2 {
+		log.Fatal("usage: goexphash diff <pkgA> <pkgB>")
+	}
+	beforePkgs, err := loadPackages(pkgNames[0])
 	if err != nil {
-		return
+		log.Fatalf("error: %s", err)
 	}
-	if *flVerbose {
-		log.Printf("package path: %s", packagePath)
+	afterPkgs, err := loadPackages(pkgNames[1])
+	if err != nil {
+		log.Fatalf("error: %s", err)
 	}
-	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, packagePath, nil, 0)
+
+	diffs, err := diffPackageSets(beforePkgs, afterPkgs)
 	if err != nil {
-		return
+		log.Fatalf("error: %s", err)
 	}
-	items := exportItems{}
-	imports := map[string]bool{}
-	for _, pkg := range pkgs {
-		for fileName, file := range pkg.Files {
-			if strings.HasSuffix(fileName, "_test.go") {
-				continue
+
+	breaking := false
+	for _, d := range diffs {
+		for _, c := range d.Changes {
+			if c.Compatibility == CompatBreaking {
+				breaking = true
 			}
-			ast.FileExports(file)
-			ast.Inspect(file, func(n ast.Node) bool {
-				switch x := n.(type) {
-				case *ast.FuncDecl:
-					fn := getFuncSignature(fset, x)
-					items = append(items, fn)
-				case *ast.GenDecl:
-					s := sprintNode(fset, x)
-					if strings.HasPrefix(s, "const (") {
-						s = strings.Replace(s, newLineDouble, newLine, -1)
-						parts := strings.Split(s, newLine)
-						for i := 1; i < len(parts)-1; i++ {
-							part := removeSpace(parts[i])
-							items = append(items, "const "+part)
-						}
-					} else if strings.HasPrefix(s, "var (") {
-						parts := strings.Split(s, newLine)
-						for i := 1; i < len(parts)-1; i++ {
-							part := removeSpace(parts[i])
-							items = append(items, "var "+part)
-						}
-					} else if strings.HasPrefix(s, "type (") {
-						parts := strings.Split(s, newLineDouble)
-						for i := 1; i < len(parts)-1; i++ {
-							part := removeSpace(parts[i])
-							items = append(items, "type "+part)
-						}
-					} else {
-						items = append(items, s)
-					}
-				case *ast.ImportSpec:
-					s := sprintNode(fset, x.Path)
-					imports[s] = true
-				}
-				return true
-			})
 		}
 	}
-	sort.Sort(items)
-	for _, item := range items {
-		if *flPrintDescriptor {
-			log.Printf("%s", item)
+
+	if *flFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		var encErr error
+		if len(diffs) == 1 {
+			encErr = enc.Encode(diffs[0].Changes)
+		} else {
+			encErr = enc.Encode(diffs)
+		}
+		if encErr != nil {
+			log.Fatalf("error: %s", encErr)
+		}
+	} else {
+		for _, d := range diffs {
+			if len(diffs) > 1 {
+				fmt.Printf("%s:\n", d.Package)
+			}
+			for _, c := range d.Changes {
+				fmt.Println(c)
+			}
 		}
-		localBuf.Write([]byte(item))
-		localBuf.Write([]byte(newLine))
 	}
-	localHash = sha512String(localBuf)
-	globalHash = sha512String(globalBuf)
-	return
-}
-
-func removeSpace(s string) string {
-	return strings.Join(strings.Fields(s), " ")
-}
 
-func getFuncSignature(fset *token.FileSet, fn *ast.FuncDecl) string {
-	buf := bytes.Buffer{}
-	printer.Fprint(&buf, fset, fn)
-	data := buf.Bytes()
-	return string(data[:bytes.IndexByte(data, '\n')-2])
-}
-
-func sprintNode(fset *token.FileSet, n ast.Node) string {
-	buf := bytes.Buffer{}
-	printer.Fprint(&buf, fset, n)
-	return string(buf.Bytes())
+	if breaking {
+		os.Exit(1)
+	}
 }
 
-func sha512String(buf bytes.Buffer) string {
-	hash := sha512.New512_256()
-	hash.Write(buf.Bytes())
-	return hex.EncodeToString(hash.Sum(nil))
+func osEnviron() []string {
+	return os.Environ()
 }
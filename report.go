@@ -0,0 +1,37 @@
+package main
+
+import (
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageReport is the structured, machine-readable description of a
+// package's exported API emitted by -format=json.
+type PackageReport struct {
+	Path       string   `json:"path"`
+	LocalHash  string   `json:"localHash"`
+	GlobalHash string   `json:"globalHash"`
+	Imports    []string `json:"imports"`
+	Symbols    []Symbol `json:"symbols"`
+}
+
+// buildReport computes the full structured report for pkg.
+func buildReport(pkg *packages.Package) (*PackageReport, error) {
+	localHash, globalHash, err := hashPackage(pkg)
+	if err != nil {
+		return nil, err
+	}
+	imports := make([]string, 0, len(pkg.Imports))
+	for importPath := range pkg.Imports {
+		imports = append(imports, importPath)
+	}
+	sort.Strings(imports)
+	return &PackageReport{
+		Path:       pkg.PkgPath,
+		LocalHash:  localHash,
+		GlobalHash: globalHash,
+		Imports:    imports,
+		Symbols:    exportedSymbols(pkg.Types),
+	}, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestBuildReport(t *testing.T) {
+	pkg, err := loadPackage("./testdata/typespkg")
+	if err != nil {
+		t.Fatalf("loadPackage: %s", err)
+	}
+	report, err := buildReport(pkg)
+	if err != nil {
+		t.Fatalf("buildReport: %s", err)
+	}
+	if report.Path != "github.com/miolini/goexphash/testdata/typespkg" {
+		t.Fatalf("unexpected Path: %s", report.Path)
+	}
+	if report.LocalHash == "" || report.GlobalHash == "" {
+		t.Fatalf("expected non-empty hashes, got local=%q global=%q", report.LocalHash, report.GlobalHash)
+	}
+	if len(report.Symbols) == 0 {
+		t.Fatalf("expected at least one exported symbol")
+	}
+	found := false
+	for _, sym := range report.Symbols {
+		if sym.Name == "Greeting" && sym.Kind == "const" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected Greeting const in report symbols: %+v", report.Symbols)
+	}
+}
@@ -0,0 +1,25 @@
+// Package diffpkg is a fixture for the diff subcommand.
+package diffpkg
+
+// Greeting is the default greeting.
+const Greeting = "hello"
+
+// Thing is an exported struct.
+type Thing struct {
+	Name string
+}
+
+// Greeter is implemented by types that can greet.
+type Greeter interface {
+	Greet() string
+}
+
+// Removed will be deleted in v2.
+func Removed() int {
+	return 1
+}
+
+// Changed will change signature in v2.
+func Changed(a int) int {
+	return a
+}
@@ -0,0 +1,27 @@
+// Package diffpkg is a fixture for the diff subcommand.
+package diffpkg
+
+// Greeting is the default greeting.
+const Greeting = "hello"
+
+// Thing is an exported struct.
+type Thing struct {
+	Name string
+	Age  int
+}
+
+// Greeter is implemented by types that can greet.
+type Greeter interface {
+	Greet() string
+	GreetLoudly() string
+}
+
+// Changed will change signature in v2.
+func Changed(a, b int) int {
+	return a + b
+}
+
+// Added is new in v2.
+func Added() int {
+	return 2
+}
@@ -0,0 +1,2 @@
+// Package fixturepkg is a tiny fixture used by dirhash tests.
+package fixturepkg
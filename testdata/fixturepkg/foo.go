@@ -0,0 +1,6 @@
+package fixturepkg
+
+// Foo returns a constant greeting.
+func Foo() string {
+	return "hello"
+}
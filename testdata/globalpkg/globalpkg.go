@@ -0,0 +1,14 @@
+// Package globalpkg imports typespkg and the standard library to exercise
+// the transitive global hash.
+package globalpkg
+
+import (
+	"strings"
+
+	"github.com/miolini/goexphash/testdata/typespkg"
+)
+
+// Shout returns typespkg.Greeting upper-cased.
+func Shout() string {
+	return strings.ToUpper(typespkg.Greeting)
+}
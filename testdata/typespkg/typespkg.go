@@ -0,0 +1,34 @@
+// Package typespkg exercises the type-aware exported API hasher.
+package typespkg
+
+// Greeting is the default greeting returned by Greet.
+const Greeting = "hello"
+
+// Point is an exported struct with one exported and one unexported field.
+type Point struct {
+	X int
+	Y int
+	z int
+}
+
+// Add returns the sum of p and q.
+func (p Point) Add(q Point) Point {
+	return Point{X: p.X + q.X, Y: p.Y + q.Y}
+}
+
+// Greeter is implemented by types that can greet.
+type Greeter interface {
+	Greet() string
+}
+
+// LoudGreeter embeds Greeter, so its exported method set includes Greet
+// even though LoudGreeter doesn't declare it directly.
+type LoudGreeter interface {
+	Greeter
+	GreetLoudly() string
+}
+
+// Greet returns Greeting.
+func Greet() string {
+	return Greeting
+}